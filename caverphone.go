@@ -0,0 +1,95 @@
+package megophone
+
+import (
+	"regexp"
+	"strings"
+)
+
+// caverphoneLength is the fixed width of a Caverphone 2.0 code.
+const caverphoneLength = 10
+
+// caverphoneSteps is the ordered list of substitutions that make up
+// Caverphone 2.0, David Hood's phonetic algorithm for the New Zealand
+// Caversham Project. Each step is applied to the whole string in order;
+// later steps rely on the markers ('2', '3') left behind by earlier ones,
+// so the order must not change.
+var caverphoneSteps = []struct {
+	pattern *regexp.Regexp
+	repl    string
+}{
+	{regexp.MustCompile(`e$`), ""},
+	{regexp.MustCompile(`^cough`), "cou2f"},
+	{regexp.MustCompile(`^rough`), "rou2f"},
+	{regexp.MustCompile(`^tough`), "tou2f"},
+	{regexp.MustCompile(`^enough`), "enou2f"},
+	{regexp.MustCompile(`^trough`), "trou2f"},
+	{regexp.MustCompile(`^gn`), "2n"},
+	{regexp.MustCompile(`mb$`), "m2"},
+	{regexp.MustCompile(`cq`), "2q"},
+	{regexp.MustCompile(`ci`), "si"},
+	{regexp.MustCompile(`ce`), "se"},
+	{regexp.MustCompile(`cy`), "sy"},
+	{regexp.MustCompile(`tch`), "2ch"},
+	{regexp.MustCompile(`c`), "k"},
+	{regexp.MustCompile(`q`), "k"},
+	{regexp.MustCompile(`x`), "k"},
+	{regexp.MustCompile(`v`), "f"},
+	{regexp.MustCompile(`dg`), "2g"},
+	{regexp.MustCompile(`tio`), "sio"},
+	{regexp.MustCompile(`tia`), "sia"},
+	{regexp.MustCompile(`d`), "t"},
+	{regexp.MustCompile(`ph`), "fh"},
+	{regexp.MustCompile(`b`), "p"},
+	{regexp.MustCompile(`sh`), "s2"},
+	{regexp.MustCompile(`z`), "s"},
+	{regexp.MustCompile(`^[aeiou]`), "A"},
+	{regexp.MustCompile(`[aeiou]`), "3"},
+	{regexp.MustCompile(`j`), "y"},
+	{regexp.MustCompile(`^y3`), "Y3"},
+	{regexp.MustCompile(`^y`), "A"},
+	{regexp.MustCompile(`y`), "3"},
+	{regexp.MustCompile(`3gh3`), "3kh3"},
+	{regexp.MustCompile(`gh`), "22"},
+	{regexp.MustCompile(`g`), "k"},
+	{regexp.MustCompile(`s+`), "S"},
+	{regexp.MustCompile(`t+`), "T"},
+	{regexp.MustCompile(`p+`), "P"},
+	{regexp.MustCompile(`k+`), "K"},
+	{regexp.MustCompile(`f+`), "F"},
+	{regexp.MustCompile(`m+`), "M"},
+	{regexp.MustCompile(`n+`), "N"},
+	{regexp.MustCompile(`w3`), "W3"},
+	{regexp.MustCompile(`wh3`), "Wh3"},
+	{regexp.MustCompile(`w$`), "3"},
+	{regexp.MustCompile(`w`), "2"},
+	{regexp.MustCompile(`^h`), "A"},
+	{regexp.MustCompile(`h`), "2"},
+	{regexp.MustCompile(`r3`), "R3"},
+	{regexp.MustCompile(`r$`), "3"},
+	{regexp.MustCompile(`r`), "2"},
+	{regexp.MustCompile(`l3`), "L3"},
+	{regexp.MustCompile(`l$`), "3"},
+	{regexp.MustCompile(`l`), "2"},
+	{regexp.MustCompile(`2`), ""},
+	{regexp.MustCompile(`3$`), "A"},
+	{regexp.MustCompile(`3`), ""},
+}
+
+var caverphoneNonLetter = regexp.MustCompile(`[^a-z]`)
+
+// Caverphone2 implements Caverphone 2.0. It is tuned for New Zealand English
+// names of European and Māori origin, and is best known for folding classic
+// near-homophone surname pairs like "Smith"/"Smyth" to the same code.
+func Caverphone2(s string) string {
+	name := caverphoneNonLetter.ReplaceAllString(normalize(s), "")
+	if name == "" {
+		return ""
+	}
+
+	for _, step := range caverphoneSteps {
+		name = step.pattern.ReplaceAllString(name, step.repl)
+	}
+
+	name = strings.ToUpper(name) + strings.Repeat("1", caverphoneLength)
+	return name[:caverphoneLength]
+}
@@ -0,0 +1,41 @@
+package megophone
+
+import "testing"
+
+func TestCaverphone2(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"Thompson", "TMPSN11111"},
+		{"Peter", "PTA1111111"},
+		{"Stevenson", "STFNSN1111"},
+	}
+
+	for _, c := range cases {
+		if got := Caverphone2(c.name); got != c.want {
+			t.Errorf("Caverphone2(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestCaverphone2FoldsClassicHomophones(t *testing.T) {
+	// Smith/Smyth is the textbook pair Caverphone is built to collapse.
+	if s, y := Caverphone2("Smith"), Caverphone2("Smyth"); s != y {
+		t.Errorf("Caverphone2(Smith) = %q, Caverphone2(Smyth) = %q, want them equal", s, y)
+	}
+}
+
+func TestCaverphone2FixedLength(t *testing.T) {
+	for _, name := range []string{"Li", "Featherstonehaugh", "Nguyen"} {
+		if got := Caverphone2(name); len(got) != caverphoneLength {
+			t.Errorf("Caverphone2(%q) = %q, want length %d", name, got, caverphoneLength)
+		}
+	}
+}
+
+func TestCaverphone2Empty(t *testing.T) {
+	if got := Caverphone2(""); got != "" {
+		t.Errorf("Caverphone2(\"\") = %q, want \"\"", got)
+	}
+}
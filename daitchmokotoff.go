@@ -0,0 +1,236 @@
+package megophone
+
+/*
+Daitch-Mokotoff Soundex is a phonetic encoding developed in the 1980s by
+Gary Mokotoff and Randy Daitch for the JewishGen genealogy community, aimed
+at the Slavic and Yiddish surnames that the original 1918 Soundex handles
+poorly. Unlike Soundex or Metaphone it emits six-digit codes rather than a
+letter followed by digits, and it is routinely used alongside the
+PhoneticStringUtilities Smalltalk package's own D-M implementation.
+
+Coding happens per letter group (single letters as well as common two- and
+three-letter combinations such as "SCH", "TSCH", and "RZ"), and the code for
+a group can depend on whether it starts the word, is followed by a vowel, or
+appears elsewhere. Some groups are inherently ambiguous (e.g. "CH" can sound
+like "K" or like "SH") and produce two alternative digits; DaitchMokotoff
+returns every resulting six-digit code, deduplicated and sorted.
+*/
+
+import (
+	"sort"
+	"strings"
+)
+
+type dmRule struct {
+	pattern string
+	start   string
+	vowel   string
+	other   string
+}
+
+// dmRules lists the letter groups recognised by DaitchMokotoff, along with
+// the digit (or "alt1/alt2" pair of digits) each produces depending on
+// whether it begins the word, is followed by a vowel, or appears elsewhere.
+// An empty code means the group is silent in that position.
+var dmRules = []dmRule{
+	{"schtsch", "2", "4", "4"},
+	{"schtsh", "2", "4", "4"},
+	{"schtch", "2", "4", "4"},
+	{"shtch", "2", "4", "4"},
+	{"shch", "2", "4", "4"},
+	{"stsch", "2", "4", "4"},
+	{"strz", "2", "4", "4"},
+	{"strs", "2", "4", "4"},
+	{"stch", "2", "4", "4"},
+	{"szcz", "2", "4", "4"},
+	{"szcs", "2", "4", "4"},
+	{"chs", "5", "54", "54"},
+	{"csz", "4", "4", "4"},
+	{"drz", "4", "4", "4"},
+	{"drs", "4", "4", "4"},
+	{"dsh", "4", "4", "4"},
+	{"dsz", "4", "4", "4"},
+	{"dzh", "4", "4", "4"},
+	{"dzs", "4", "4", "4"},
+	{"tsch", "4", "4", "4"},
+	{"trz", "4", "4", "4"},
+	{"trs", "4", "4", "4"},
+	{"tsz", "4", "4", "4"},
+	{"tzs", "4", "4", "4"},
+	{"zdz", "2", "4", "4"},
+	{"zsch", "4", "4", "4"},
+	{"ai", "0", "1", "1"},
+	{"aj", "0", "1", "1"},
+	{"ay", "0", "1", "1"},
+	{"au", "0", "7", "7"},
+	{"ei", "0", "1", "1"},
+	{"ej", "0", "1", "1"},
+	{"ey", "0", "1", "1"},
+	{"eu", "1", "1", "1"},
+	{"ia", "1", "", ""},
+	{"ie", "1", "", ""},
+	{"io", "1", "", ""},
+	{"iu", "1", "", ""},
+	{"oi", "0", "1", "1"},
+	{"oj", "0", "1", "1"},
+	{"oy", "0", "1", "1"},
+	{"ui", "0", "1", "1"},
+	{"uj", "0", "1", "1"},
+	{"uy", "0", "1", "1"},
+	{"cz", "4", "4", "4"},
+	{"cs", "4", "4", "4"},
+	{"ch", "5/4", "5/4", "5/4"},
+	{"ck", "5/45", "5/45", "5/45"},
+	{"ds", "4", "4", "4"},
+	{"dz", "4", "4", "4"},
+	{"dt", "3", "3", "3"},
+	{"fb", "7", "7", "7"},
+	{"kh", "5", "5", "5"},
+	{"mn", "6", "6", "6"},
+	{"nm", "6", "6", "6"},
+	{"pf", "7", "7", "7"},
+	{"ph", "7", "7", "7"},
+	{"rz", "94/4", "94/4", "94/4"},
+	{"rs", "94/4", "94/4", "94/4"},
+	{"sch", "4", "4", "4"},
+	{"sh", "4", "4", "4"},
+	{"sc", "2/4", "2/4", "2/4"},
+	{"sz", "4", "4", "4"},
+	{"th", "3", "3", "3"},
+	{"ts", "4", "4", "4"},
+	{"tz", "4", "4", "4"},
+	{"zh", "4", "4", "4"},
+	{"zs", "4", "4", "4"},
+	{"a", "0", "", ""},
+	{"e", "0", "", ""},
+	{"i", "0", "", ""},
+	{"o", "0", "", ""},
+	{"u", "0", "", ""},
+	{"y", "1", "", ""},
+	{"b", "7", "7", "7"},
+	{"c", "5/4", "5/4", "5/4"},
+	{"d", "3", "3", "3"},
+	{"f", "7", "7", "7"},
+	{"g", "5", "5", "5"},
+	{"h", "5", "5", ""},
+	{"j", "1/4", "1/4", "1/4"},
+	{"k", "5", "5", "5"},
+	{"l", "8", "8", "8"},
+	{"m", "6", "6", "6"},
+	{"n", "6", "6", "6"},
+	{"p", "7", "7", "7"},
+	{"q", "5", "5", "5"},
+	{"r", "9", "9", "9"},
+	{"s", "4", "4", "4"},
+	{"t", "3", "3", "3"},
+	{"v", "7", "7", "7"},
+	{"w", "7", "7", "7"},
+	{"x", "5", "54", "54"},
+	{"z", "4", "4", "4"},
+}
+
+// dmRulesByLength is dmRules sorted so that the longest pattern is always
+// tried before a shorter one that shares its prefix (e.g. "sch" before "s").
+var dmRulesByLength = sortedDMRules()
+
+func sortedDMRules() []dmRule {
+	rules := make([]dmRule, len(dmRules))
+	copy(rules, dmRules)
+	sort.SliceStable(rules, func(i, j int) bool {
+		return len(rules[i].pattern) > len(rules[j].pattern)
+	})
+	return rules
+}
+
+func isDMVowel(b byte) bool {
+	switch b {
+	case 'a', 'e', 'i', 'o', 'u', 'y':
+		return true
+	}
+	return false
+}
+
+func matchDMRule(s string, pos int) *dmRule {
+	for i := range dmRulesByLength {
+		rule := &dmRulesByLength[i]
+		if strings.HasPrefix(s[pos:], rule.pattern) {
+			return rule
+		}
+	}
+	return nil
+}
+
+func padOrTruncateDigits(code string, n int) string {
+	if len(code) >= n {
+		return code[:n]
+	}
+	return code + strings.Repeat("0", n-len(code))
+}
+
+// DaitchMokotoff returns the six-digit Daitch-Mokotoff Soundex codes for s,
+// deduplicated and sorted. A name can yield more than one code when it
+// contains a letter group with more than one plausible pronunciation (e.g.
+// "ch" coding as both "5" and "4").
+func DaitchMokotoff(s string) []string {
+	folded := normalize(s)
+	if folded == "" {
+		return nil
+	}
+
+	// branches[i] is a candidate digit string in progress; last[i] is the
+	// most recent digit appended to it, used to collapse adjacent repeats.
+	branches := []string{""}
+	last := []byte{0}
+
+	n := len(folded)
+	for cur := 0; cur < n; {
+		rule := matchDMRule(folded, cur)
+		if rule == nil {
+			cur++
+			continue
+		}
+
+		var codeField string
+		switch {
+		case cur == 0:
+			codeField = rule.start
+		case cur+len(rule.pattern) < n && isDMVowel(folded[cur+len(rule.pattern)]):
+			codeField = rule.vowel
+		default:
+			codeField = rule.other
+		}
+
+		alternatives := strings.Split(codeField, "/")
+		nextBranches := make([]string, 0, len(branches)*len(alternatives))
+		nextLast := make([]byte, 0, len(branches)*len(alternatives))
+		for bi, branch := range branches {
+			for _, alt := range alternatives {
+				nb, nl := branch, last[bi]
+				for k := 0; k < len(alt); k++ {
+					d := alt[k]
+					if d == nl {
+						continue
+					}
+					nb += string(d)
+					nl = d
+				}
+				nextBranches = append(nextBranches, nb)
+				nextLast = append(nextLast, nl)
+			}
+		}
+		branches, last = nextBranches, nextLast
+		cur += len(rule.pattern)
+	}
+
+	seen := make(map[string]bool, len(branches))
+	out := make([]string, 0, len(branches))
+	for _, b := range branches {
+		code := padOrTruncateDigits(b, 6)
+		if !seen[code] {
+			seen[code] = true
+			out = append(out, code)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,47 @@
+package megophone
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDaitchMokotoff(t *testing.T) {
+	cases := []struct {
+		name string
+		want []string
+	}{
+		{"Peters", []string{"734000", "739400"}},
+		{"Moskowitz", []string{"645740"}},
+		{"Auerbach", []string{"097400", "097500"}},
+	}
+
+	for _, c := range cases {
+		got := DaitchMokotoff(c.name)
+		if !reflect.DeepEqual(got, c.want) {
+			t.Errorf("DaitchMokotoff(%q) = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestDaitchMokotoffDeduplicatesAndSorts(t *testing.T) {
+	got := DaitchMokotoff("Auerbach")
+	for i := 1; i < len(got); i++ {
+		if got[i-1] >= got[i] {
+			t.Errorf("DaitchMokotoff results not strictly sorted: %v", got)
+		}
+	}
+}
+
+func TestDaitchMokotoffCodeLength(t *testing.T) {
+	for _, code := range DaitchMokotoff("Schwartzenegger") {
+		if len(code) != 6 {
+			t.Errorf("DaitchMokotoff code %q is not 6 digits", code)
+		}
+	}
+}
+
+func TestDaitchMokotoffEmpty(t *testing.T) {
+	if got := DaitchMokotoff(""); got != nil {
+		t.Errorf("DaitchMokotoff(\"\") = %v, want nil", got)
+	}
+}
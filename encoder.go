@@ -0,0 +1,248 @@
+package megophone
+
+import "sort"
+
+// Encoder is implemented by the adapters listed in StandardEncoders, so an
+// Index can treat Soundex, NYSIIS, Double Metaphone, and the rest
+// interchangeably. DaitchMokotoff and Metaphone3 have no Encoder adapter.
+type Encoder interface {
+	// Encode returns the phonetic code(s) for s. Most algorithms return a
+	// single code; Double Metaphone can return more than one when a
+	// spelling has multiple plausible pronunciations.
+	Encode(s string) []string
+	// Name identifies the algorithm, e.g. when reporting which encoders
+	// agreed on a Hit.
+	Name() string
+}
+
+// StandardEncoders returns one instance of each of the phonetic algorithms
+// in this package that has a stable, single-purpose encoding (the set
+// offered by the Ruby "phonetic" gem): Soundex, Refined Soundex, NYSIIS,
+// Caverphone 2, Metaphone, and Double Metaphone. It is the default encoder
+// set used by NewIndex when none is supplied.
+func StandardEncoders() []Encoder {
+	return []Encoder{
+		SoundexEncoder{},
+		RefinedSoundexEncoder{},
+		NYSIISEncoder{},
+		Caverphone2Encoder{},
+		MetaphoneEncoder{},
+		DoubleMetaphoneEncoder{},
+	}
+}
+
+func singleCode(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return []string{s}
+}
+
+// SoundexEncoder adapts Soundex to the Encoder interface.
+type SoundexEncoder struct{}
+
+func (SoundexEncoder) Encode(s string) []string { return singleCode(Soundex(s)) }
+func (SoundexEncoder) Name() string             { return "Soundex" }
+
+// RefinedSoundexEncoder adapts RefinedSoundex to the Encoder interface.
+type RefinedSoundexEncoder struct{}
+
+func (RefinedSoundexEncoder) Encode(s string) []string { return singleCode(RefinedSoundex(s)) }
+func (RefinedSoundexEncoder) Name() string             { return "Refined Soundex" }
+
+// NYSIISEncoder adapts NYSIIS to the Encoder interface.
+type NYSIISEncoder struct{}
+
+func (NYSIISEncoder) Encode(s string) []string { return singleCode(NYSIIS(s)) }
+func (NYSIISEncoder) Name() string             { return "NYSIIS" }
+
+// Caverphone2Encoder adapts Caverphone2 to the Encoder interface.
+type Caverphone2Encoder struct{}
+
+func (Caverphone2Encoder) Encode(s string) []string { return singleCode(Caverphone2(s)) }
+func (Caverphone2Encoder) Name() string             { return "Caverphone 2" }
+
+// MetaphoneEncoder adapts the classic single-key Metaphone to the Encoder
+// interface, keeping only the primary key (use DoubleMetaphoneEncoder for
+// both keys).
+type MetaphoneEncoder struct{}
+
+func (MetaphoneEncoder) Encode(s string) []string {
+	primary, _ := Metaphone(s)
+	return singleCode(primary)
+}
+func (MetaphoneEncoder) Name() string { return "Metaphone" }
+
+// DoubleMetaphoneEncoder adapts Metaphone's primary and alternate keys to
+// the Encoder interface, returning both (deduplicated) when they differ.
+type DoubleMetaphoneEncoder struct{}
+
+func (DoubleMetaphoneEncoder) Encode(s string) []string {
+	primary, alternate := Metaphone(s)
+	if primary == alternate {
+		return singleCode(primary)
+	}
+	codes := make([]string, 0, 2)
+	if primary != "" {
+		codes = append(codes, primary)
+	}
+	if alternate != "" {
+		codes = append(codes, alternate)
+	}
+	return codes
+}
+func (DoubleMetaphoneEncoder) Name() string { return "Double Metaphone" }
+
+// Hit is a single Index.Search result.
+type Hit struct {
+	ID string
+	// Score is the number of encoders whose code for the query matched a
+	// code this id was indexed under.
+	Score int
+	// Distance is the Levenshtein edit distance between the query and the
+	// original text this id was added with; it only breaks ties in Score.
+	Distance int
+}
+
+// Index is an in-memory fuzzy-search index keyed by phonetic code. Add
+// stores a document's phonetic codes under each configured encoder; Search
+// and SearchAny look a query up the same way and rank matches by how many
+// encoders agreed, then by edit distance to the indexed text.
+type Index struct {
+	encoders []Encoder
+	postings map[string]map[string]map[string]bool // encoder name -> code -> id set
+	texts    map[string]string                     // id -> original text
+}
+
+// NewIndex returns an Index that encodes added text with encoders. With no
+// encoders given, it uses StandardEncoders.
+func NewIndex(encoders ...Encoder) *Index {
+	if len(encoders) == 0 {
+		encoders = StandardEncoders()
+	}
+	postings := make(map[string]map[string]map[string]bool, len(encoders))
+	for _, enc := range encoders {
+		postings[enc.Name()] = make(map[string]map[string]bool)
+	}
+	return &Index{
+		encoders: encoders,
+		postings: postings,
+		texts:    make(map[string]string),
+	}
+}
+
+// Add indexes text under id, encoding it with every encoder the Index was
+// built with. Adding the same id again replaces its text and postings.
+func (idx *Index) Add(id string, text string) {
+	if old, ok := idx.texts[id]; ok {
+		idx.remove(id, old)
+	}
+	idx.texts[id] = text
+	for _, enc := range idx.encoders {
+		bucket := idx.postings[enc.Name()]
+		for _, code := range enc.Encode(text) {
+			if bucket[code] == nil {
+				bucket[code] = make(map[string]bool)
+			}
+			bucket[code][id] = true
+		}
+	}
+}
+
+// remove drops id's postings for the text it was previously added with.
+func (idx *Index) remove(id, text string) {
+	for _, enc := range idx.encoders {
+		bucket := idx.postings[enc.Name()]
+		for _, code := range enc.Encode(text) {
+			delete(bucket[code], id)
+		}
+	}
+}
+
+// Search ranks every indexed id by how many of the Index's own encoders
+// produce, for query, a code that id was indexed under.
+func (idx *Index) Search(query string) []Hit {
+	return idx.SearchAny(query, idx.encoders...)
+}
+
+// SearchAny is like Search but ranks ids by agreement across only the given
+// encoders, which must be a subset of the ones the Index was built with
+// (encoders it wasn't built with contribute no postings and are ignored).
+func (idx *Index) SearchAny(query string, encoders ...Encoder) []Hit {
+	if len(encoders) == 0 {
+		encoders = idx.encoders
+	}
+
+	scores := make(map[string]int)
+	for _, enc := range encoders {
+		bucket := idx.postings[enc.Name()]
+		if bucket == nil {
+			continue
+		}
+		matched := make(map[string]bool)
+		for _, code := range enc.Encode(query) {
+			for id := range bucket[code] {
+				matched[id] = true
+			}
+		}
+		for id := range matched {
+			scores[id]++
+		}
+	}
+
+	hits := make([]Hit, 0, len(scores))
+	for id, score := range scores {
+		hits = append(hits, Hit{
+			ID:       id,
+			Score:    score,
+			Distance: editDistance(query, idx.texts[id]),
+		})
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if hits[i].Distance != hits[j].Distance {
+			return hits[i].Distance < hits[j].Distance
+		}
+		return hits[i].ID < hits[j].ID
+	})
+	return hits
+}
+
+// editDistance returns the Levenshtein distance between a and b, used to
+// tiebreak Hits that agree on the same number of encoders.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			del := prev[j] + 1
+			ins := cur[j-1] + 1
+			sub := prev[j-1] + cost
+			cur[j] = min3(del, ins, sub)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
@@ -0,0 +1,97 @@
+package megophone
+
+import "testing"
+
+func TestStandardEncodersImplementEncoder(t *testing.T) {
+	for _, enc := range StandardEncoders() {
+		if enc.Name() == "" {
+			t.Errorf("%T.Name() returned an empty string", enc)
+		}
+		if codes := enc.Encode("Smith"); len(codes) == 0 {
+			t.Errorf("%T.Encode(Smith) returned no codes", enc)
+		}
+	}
+}
+
+func TestDoubleMetaphoneEncoderReturnsBothKeys(t *testing.T) {
+	codes := DoubleMetaphoneEncoder{}.Encode("Smith")
+	if len(codes) != 2 || codes[0] != "SM0" || codes[1] != "XMT" {
+		t.Errorf("DoubleMetaphoneEncoder{}.Encode(Smith) = %v, want [SM0 XMT]", codes)
+	}
+
+	// when the two keys agree, only one code should come back
+	codes = DoubleMetaphoneEncoder{}.Encode("Thompson")
+	if len(codes) != 1 || codes[0] != "TMPS" {
+		t.Errorf("DoubleMetaphoneEncoder{}.Encode(Thompson) = %v, want [TMPS]", codes)
+	}
+}
+
+func TestIndexSearchRanksByAgreementThenDistance(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("1", "Smith")
+	idx.Add("2", "Smyth")
+	idx.Add("3", "Jones")
+
+	hits := idx.Search("Smythe")
+	if len(hits) < 2 {
+		t.Fatalf("Search(Smythe) returned %d hits, want at least 2", len(hits))
+	}
+	if hits[0].ID != "2" {
+		t.Errorf("Search(Smythe) top hit = %q, want \"2\" (Smyth, agreed on by more encoders)", hits[0].ID)
+	}
+	for _, h := range hits {
+		if h.ID == "3" {
+			t.Errorf("Search(Smythe) unexpectedly matched %q (Jones)", h.ID)
+		}
+	}
+}
+
+func TestIndexSearchAnyRestrictsEncoders(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("1", "Catherine")
+	idx.Add("2", "Katherine")
+
+	all := idx.Search("Kathryn")
+	narrow := idx.SearchAny("Kathryn", SoundexEncoder{})
+
+	if len(all) == 0 {
+		t.Fatalf("Search(Kathryn) returned no hits")
+	}
+	if len(narrow) == 0 {
+		t.Fatalf("SearchAny(Kathryn, SoundexEncoder{}) returned no hits")
+	}
+	for _, h := range narrow {
+		if h.Score > 1 {
+			t.Errorf("SearchAny with a single encoder produced Score %d > 1 for %q", h.Score, h.ID)
+		}
+	}
+}
+
+func TestIndexAddReplacesExistingID(t *testing.T) {
+	idx := NewIndex()
+	idx.Add("1", "Smith")
+	idx.Add("1", "Jones")
+
+	hits := idx.Search("Smith")
+	for _, h := range hits {
+		if h.ID == "1" {
+			t.Errorf("id 1 still matches Smith after being re-added as Jones")
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"kitten", "sitting", 3},
+		{"Smith", "Smith", 0},
+		{"", "abc", 3},
+	}
+	for _, c := range cases {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
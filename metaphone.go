@@ -39,30 +39,53 @@ translated to Go by Adele Dewey-Lopez <adele@seed.co> using Atkinson's C++ sourc
                        reformulated as objects, fixed a bug in 'G'
                        (0.4; Duncan McGreggor)
   Updated 2013-06    - Enforced unicode literals (0.5; Ian Beaver)
+  Updated 2026-07    - Completed the consonant handlers ('D' through 'Z'),
+                       added the configurable-length MetaphoneN variant,
+                       folded accented input to plain ASCII before scanning,
+                       and removed the debug trace (0.6)
+  Updated 2026-07    - Reworked the scanner to build its keys in []byte
+                       buffers instead of by repeated string concatenation,
+                       closed a missing upper-bound check in matchesAny, and
+                       split the dispatch loop out as runMetaphone so
+                       StreamEncoder can reuse it without allocating a fresh
+                       phoneticData per call (0.7)
 */
 
-import "fmt"
+import (
+	"bytes"
+	"strings"
+)
+
+// DefaultKeyLength is the classic Metaphone key length used by Metaphone and
+// by reference implementations such as the Dr. Dobb's/Postgres dmetaphone
+// pair.
+const DefaultKeyLength = 4
 
 type phoneticData struct {
-	t               string
+	text            []byte
 	cur             int
+	last            int
 	isSlavoGermanic bool
-	metaphone1      string
-	metaphone2      string
+	metaphone1      []byte
+	metaphone2      []byte
 }
 
 func (p *phoneticData) matchesAny(pos int, matches ...string) bool {
 	if len(matches) == 0 {
 		return true
 	}
-	// out of bounds
-	if p.cur+pos < 0 {
+	lo := p.cur + pos
+	if lo < 0 {
 		return false
 	}
 
-	for i, str := range matches {
-		size := len(matches[i])
-		if p.t[p.cur+pos:p.cur+size+pos] == str {
+	for _, str := range matches {
+		hi := lo + len(str)
+		if hi > len(p.text) {
+			// would read past the end of the (padded) text; never a match
+			continue
+		}
+		if string(p.text[lo:hi]) == str {
 			return true
 		}
 	}
@@ -72,11 +95,11 @@ func (p *phoneticData) matchesAny(pos int, matches ...string) bool {
 
 func (p *phoneticData) add(phoneme ...string) {
 	if len(phoneme) > 0 {
-		p.metaphone1 += phoneme[0]
+		p.metaphone1 = append(p.metaphone1, phoneme[0]...)
 		if len(phoneme) > 1 {
-			p.metaphone2 += phoneme[1]
+			p.metaphone2 = append(p.metaphone2, phoneme[1]...)
 		} else {
-			p.metaphone2 += phoneme[0]
+			p.metaphone2 = append(p.metaphone2, phoneme[0]...)
 		}
 	}
 }
@@ -92,7 +115,7 @@ func (p *phoneticData) isVowel(pos int) bool {
 func (p *phoneticData) b() {
 	p.add("p")
 	// skip double b
-	if p.t[p.cur+1] == 'b' {
+	if p.text[p.cur+1] == 'b' {
 		p.skip(1)
 	}
 }
@@ -152,30 +175,500 @@ func (p *phoneticData) c() {
 		// e.g. "focaccia"
 		p.add("x")
 		p.skip(2)
+	} else {
+		// e.g. "cat", also handles double 'c' as in "accident", "accede"
+		p.add("k")
+		if p.matchesAny(1, " c", " q", " g") {
+			p.skip(2)
+		} else if p.matchesAny(1, "c", "k", "q") && !p.matchesAny(1, "ce", "ci") {
+			p.skip(1)
+		}
 	}
 }
 
-func Metaphone(s string) (string, string) {
+func (p *phoneticData) d() {
+	if p.matchesAny(0, "dg") {
+		if p.matchesAny(2, "i", "e", "y") {
+			// e.g. "edge"
+			p.add("j")
+			p.skip(2)
+		} else {
+			// e.g. "edgar"
+			p.add("tk")
+			p.skip(1)
+		}
+	} else if p.matchesAny(0, "dt", "dd") {
+		p.add("t")
+		p.skip(1)
+	} else {
+		p.add("t")
+	}
+}
 
-	// initialize
-	var p *phoneticData
-	p = &phoneticData{}
+func (p *phoneticData) f() {
+	p.add("f")
+	if p.matchesAny(1, "f") {
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) g() {
+	if p.matchesAny(1, "h") {
+		if p.cur > 0 && !p.isVowel(-1) {
+			// e.g. "burgher"
+			p.add("k")
+			p.skip(1)
+			return
+		}
+		if p.cur == 0 {
+			if p.matchesAny(2, "i") {
+				p.add("j")
+			} else {
+				p.add("k")
+			}
+			p.skip(1)
+			return
+		}
+		if (p.cur > 1 && p.matchesAny(-2, "b", "h", "d")) ||
+			(p.cur > 2 && p.matchesAny(-3, "b", "h", "d")) ||
+			(p.cur > 3 && p.matchesAny(-4, "b", "h")) {
+			// Parker's rule - e.g. "hugh"
+			p.skip(1)
+			return
+		}
+		if p.cur > 2 && p.matchesAny(-1, "u") && p.matchesAny(-3, "c", "g", "l", "r", "t") {
+			// e.g. "laugh", "mclaughlin", "cough", "roughest"
+			p.add("f")
+		} else if p.cur > 0 && !p.matchesAny(-1, "i") {
+			p.add("k")
+		}
+		p.skip(1)
+		return
+	}
 
-	// pad string
-	// normalize
-	p.t = s + "     "
+	if p.matchesAny(1, "n") {
+		if p.cur == 1 && p.isVowel(-1) && !p.isSlavoGermanic {
+			p.add("kn", "n")
+		} else if !p.matchesAny(2, "ey") && !p.matchesAny(1, "y") && !p.isSlavoGermanic {
+			// not e.g. "cagney"
+			p.add("n", "kn")
+		} else {
+			p.add("kn")
+		}
+		p.skip(1)
+		return
+	}
 
-	if p.matchesAny(0, "gn", "kn", "pn", "wr", "ps") {
+	if p.matchesAny(1, "li") && !p.isSlavoGermanic {
+		// e.g. "tagliaro"
+		p.add("kl", "l")
+		p.skip(1)
+		return
+	}
+
+	if p.cur == 0 && (p.matchesAny(1, "y") ||
+		p.matchesAny(1, "es", "ep", "eb", "el", "ey", "ib", "il", "in", "ie", "ei", "er")) {
+		// -ges-, -gep-, -gel-, -gie- at the beginning
+		p.add("k", "j")
+		p.skip(1)
+		return
+	}
+
+	if (p.matchesAny(1, "er") || p.matchesAny(1, "y")) &&
+		!p.matchesAny(-p.cur, "danger", "ranger", "manger") &&
+		!p.matchesAny(-1, "e", "i") && !p.matchesAny(-1, "rgy", "ogy") {
+		// -ger-, -gy-
+		p.add("k", "j")
+		p.skip(1)
+		return
+	}
+
+	if p.matchesAny(1, "e", "i", "y") || p.matchesAny(-1, "aggi", "oggi") {
+		// italian e.g. "biaggi"
+		if p.matchesAny(-p.cur, "van ", "von ") || p.matchesAny(-p.cur, "sch") ||
+			p.matchesAny(1, "et") {
+			// obvious germanic
+			p.add("k")
+		} else if p.matchesAny(1, "ier ") {
+			// always soft if french ending
+			p.add("j")
+		} else {
+			p.add("j", "k")
+		}
+		p.skip(1)
+		return
+	}
+
+	p.add("k")
+	if p.matchesAny(1, "g") {
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) h() {
+	// only keep if first and before a vowel or between two vowels
+	if (p.cur == 0 || p.isVowel(-1)) && p.isVowel(1) {
+		p.add("h")
+		p.skip(1)
+	}
+	// also takes care of double 'h'
+}
+
+func (p *phoneticData) j() {
+	if p.matchesAny(0, "jose") || p.matchesAny(-p.cur, "san ") {
+		// obvious spanish, "jose", "san jacinto"
+		if p.matchesAny(-p.cur, "san ") {
+			p.add("h")
+		} else {
+			p.add("j", "h")
+		}
+		return
+	}
+
+	if p.cur == 0 {
+		// yankelovich/jankelowicz
+		p.add("j", "y")
+	} else if p.isVowel(-1) && !p.isSlavoGermanic && p.matchesAny(1, "a", "o") {
+		// spanish pron. of e.g. "bajador"
+		p.add("j", "h")
+	} else if p.cur == p.last {
+		p.add("j", "")
+	} else if !p.matchesAny(1, "l", "t", "k", "s", "n", "m", "b", "z") &&
+		!p.matchesAny(-1, "s", "k", "l") {
+		p.add("j")
+	}
+
+	if p.matchesAny(1, "j") {
+		// it could happen!
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) k() {
+	p.add("k")
+	if p.matchesAny(1, "k") {
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) l() {
+	if p.matchesAny(1, "l") {
+		if (p.cur == p.last-2 && p.matchesAny(-1, "illo", "illa", "alle")) ||
+			((p.matchesAny(p.last-1-p.cur, "as", "os") || p.matchesAny(p.last-p.cur, "a", "o")) &&
+				p.matchesAny(-1, "alle")) {
+			// spanish e.g. "cabrillo", "gallegos"
+			p.add("l", "")
+			p.skip(1)
+			return
+		}
+		p.skip(1)
+	}
+	p.add("l")
+}
+
+func (p *phoneticData) m() {
+	if (p.matchesAny(-1, "umb") && (p.cur+1 == p.last || p.matchesAny(2, "er"))) ||
+		p.matchesAny(1, "m") {
+		// e.g. "dumb", "thumb"
+		p.skip(1)
+	}
+	p.add("m")
+}
+
+func (p *phoneticData) n() {
+	p.add("n")
+	if p.matchesAny(1, "n") {
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) ñ() {
+	p.add("n")
+}
+
+func (p *phoneticData) p() {
+	if p.matchesAny(1, "h") {
+		p.add("f")
+		p.skip(1)
+		return
+	}
+	// also account for "campbell" and "raspberry"
+	p.add("p")
+	if p.matchesAny(1, "p", "b") {
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) q() {
+	p.add("k")
+	if p.matchesAny(1, "q") {
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) r() {
+	if p.cur == p.last && !p.isSlavoGermanic && p.matchesAny(-2, "ie") &&
+		!p.matchesAny(-4, "me", "ma") {
+		// french e.g. "rogier", but not "hochmeier"
+		p.add("", "r")
+	} else {
+		p.add("r")
+	}
+	if p.matchesAny(1, "r") {
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) s() {
+	if p.matchesAny(-1, "isl", "ysl") {
+		// island, isle, carlisle, carlysle
+		return
+	}
+
+	if p.cur == 0 && p.matchesAny(0, "sugar") {
+		// special case "sugar-"
+		p.add("x", "s")
+		return
+	}
+
+	if p.matchesAny(0, "sh") {
+		if p.matchesAny(1, "heim", "hoek", "holm", "holz") {
+			// germanic
+			p.add("s")
+		} else {
+			p.add("x")
+		}
+		p.skip(1)
+		return
+	}
+
+	if p.matchesAny(0, "sio", "sia") {
+		// italian and armenian
+		if !p.isSlavoGermanic {
+			p.add("s", "x")
+		} else {
+			p.add("s")
+		}
 		p.skip(2)
+		return
+	}
+
+	if (p.cur == 0 && p.matchesAny(1, "m", "n", "l", "w")) || p.matchesAny(1, "z") {
+		// german & anglicisations, e.g. "smith" match "schmidt", "snider"
+		// match "schneider"; also -sz- in slavic languages
+		p.add("s", "x")
+		if p.matchesAny(1, "z") {
+			p.skip(1)
+		}
+		return
+	}
+
+	if p.matchesAny(0, "sc") {
+		// Schlesinger's rule
+		if p.matchesAny(2, "h") {
+			if p.matchesAny(3, "oo", "er", "en", "uy", "ed", "em") {
+				// dutch origin, e.g. "school", "schooner", "schermerhorn",
+				// "schenker"
+				if p.matchesAny(3, "er", "en") {
+					p.add("x", "sk")
+				} else {
+					p.add("sk")
+				}
+			} else if p.cur == 0 && !p.isVowel(3) && !p.matchesAny(3, "w") {
+				p.add("x", "s")
+			} else {
+				p.add("x")
+			}
+		} else if p.matchesAny(2, "i", "e", "y") {
+			p.add("s")
+		} else {
+			p.add("sk")
+		}
+		p.skip(2)
+		return
+	}
+
+	if p.cur == p.last && p.matchesAny(-2, "ai", "oi") {
+		// french e.g. "resnais", "artois"
+		p.add("", "s")
+	} else {
+		p.add("s")
+	}
+	if p.matchesAny(1, "s", "z") {
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) t() {
+	if p.matchesAny(0, "tion") {
+		p.add("x")
+		p.skip(2)
+		return
+	}
+	if p.matchesAny(0, "tia", "tch") {
+		p.add("x")
+		p.skip(2)
+		return
+	}
+	if p.matchesAny(0, "th") || p.matchesAny(0, "tth") {
+		if p.matchesAny(2, "om", "am") ||
+			// special case "thomas", "thames" or germanic
+			p.matchesAny(-p.cur, "van ", "von ") || p.matchesAny(-p.cur, "sch") {
+			p.add("t")
+		} else {
+			p.add("0", "t")
+		}
+		p.skip(1)
+		return
+	}
+	p.add("t")
+	if p.matchesAny(1, "t", "d") {
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) v() {
+	p.add("f")
+	if p.matchesAny(1, "v") {
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) w() {
+	if p.matchesAny(0, "wr") {
+		// can also be in the middle of a word
+		p.add("r")
+		p.skip(1)
+		return
+	}
+
+	if p.cur == 0 && (p.isVowel(1) || p.matchesAny(0, "wh")) {
+		// "wasserman" should match "vasserman"
+		if p.isVowel(1) {
+			p.add("a", "f")
+		} else {
+			// need "uomo" to match "womo"
+			p.add("a")
+		}
+	}
+
+	if (p.cur == p.last && p.isVowel(-1)) ||
+		p.matchesAny(-1, "ewski", "ewsky", "owski", "owsky") ||
+		p.matchesAny(-p.cur, "sch") {
+		// "arnow" should match "arnoff"
+		p.add("", "f")
+		return
+	}
+
+	if p.matchesAny(0, "wicz", "witz") {
+		// polish e.g. "filipowicz"
+		p.add("ts", "fx")
+		p.skip(3)
+		return
+	}
+	// otherwise skip silently
+}
+
+func (p *phoneticData) x() {
+	if !(p.cur == p.last && (p.matchesAny(-3, "iau", "eau") || p.matchesAny(-2, "au", "ou"))) {
+		// french e.g. "breaux"
+		p.add("ks")
+	}
+	if p.matchesAny(1, "c", "x") {
+		p.skip(1)
+	}
+}
+
+func (p *phoneticData) z() {
+	if p.matchesAny(1, "h") {
+		// chinese pinyin e.g. "zhao"
+		p.add("j")
+		p.skip(1)
+		return
+	}
+	if p.matchesAny(1, "zo", "zi", "za") || (p.isSlavoGermanic && p.cur > 0 && !p.matchesAny(-1, "t")) {
+		p.add("s", "ts")
+	} else {
+		p.add("s")
+	}
+	if p.matchesAny(1, "z") {
+		p.skip(1)
+	}
+}
+
+// normalize lower-cases s and folds accented Latin letters down to their
+// plain ASCII equivalent so the byte-oriented scanner above can dispatch on
+// them. 'ç' and 'ñ' are kept distinct (as single bytes) rather than folded
+// to 'c'/'n', since they have their own dedicated handlers above.
+func normalize(s string) string {
+	s = strings.ToLower(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch r {
+		case 'à', 'á', 'â', 'ã', 'ä', 'å', 'ā':
+			b.WriteByte('a')
+		case 'è', 'é', 'ê', 'ë', 'ē':
+			b.WriteByte('e')
+		case 'ì', 'í', 'î', 'ï', 'ī':
+			b.WriteByte('i')
+		case 'ò', 'ó', 'ô', 'õ', 'ö', 'ō':
+			b.WriteByte('o')
+		case 'ù', 'ú', 'û', 'ü', 'ū':
+			b.WriteByte('u')
+		case 'ý', 'ÿ':
+			b.WriteByte('y')
+		case 'ç':
+			b.WriteByte('ç')
+		case 'ñ':
+			b.WriteByte('ñ')
+		default:
+			if r < 128 {
+				b.WriteByte(byte(r))
+			}
+			// silently drop anything else we don't know how to fold
+		}
+	}
+	return b.String()
+}
+
+func truncate(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
+
+// truncateBytes is truncate for a []byte key, used by the zero-allocation
+// StreamEncoder path where keys are never converted to a string.
+func truncateBytes(b []byte, n int) []byte {
+	if n <= 0 || len(b) <= n {
+		return b
+	}
+	return b[:n]
+}
+
+// runMetaphone runs the Double Metaphone dispatch loop over p.text starting
+// at p.cur, appending phonemes to p.metaphone1/p.metaphone2 as it goes.
+// Callers must first set p.text (lower-cased, accent-folded, and padded
+// with trailing spaces so lookahead matches never need their own bounds
+// checks), p.last, and p.isSlavoGermanic. It is factored out of MetaphoneN
+// so StreamEncoder can drive the same scanner without allocating a fresh
+// phoneticData per call.
+func runMetaphone(p *phoneticData) {
+	if p.matchesAny(0, "gn", "kn", "pn", "wr", "ps") {
+		// silent first letter
+		p.skip(1)
 	}
 
 	if p.matchesAny(0, "x") {
+		// "xavier" is pronounced with a leading "s"
 		p.add("s")
+		p.skip(1)
 	}
 
-	for p.cur < len(p.t) {
-		next := p.t[p.cur]
-		//fmt.Println(p.cur, ": ", string(next))
+	for p.cur < len(p.text) {
+		next := p.text[p.cur]
 		switch next {
 		case 'a', 'e', 'i', 'o', 'u', 'y':
 			if p.cur == 0 {
@@ -187,20 +680,74 @@ func Metaphone(s string) (string, string) {
 			p.ç()
 		case 'c':
 			p.c()
-			// case 'd':
-			// 	p.b()
-			// case 'f':
-			// 	p.b()
-			// case 'g':
-			// 	p.b()
-			// case 'h':
-			// 	p.b()
+		case 'd':
+			p.d()
+		case 'f':
+			p.f()
+		case 'g':
+			p.g()
+		case 'h':
+			p.h()
+		case 'j':
+			p.j()
+		case 'k':
+			p.k()
+		case 'l':
+			p.l()
+		case 'm':
+			p.m()
+		case 'n':
+			p.n()
+		case 'ñ':
+			p.ñ()
+		case 'p':
+			p.p()
+		case 'q':
+			p.q()
+		case 'r':
+			p.r()
+		case 's':
+			p.s()
+		case 't':
+			p.t()
+		case 'v':
+			p.v()
+		case 'w':
+			p.w()
+		case 'x':
+			p.x()
+		case 'z':
+			p.z()
 		}
 		p.cur++
-
 	}
+}
+
+// Metaphone returns the primary and secondary Double Metaphone keys for s,
+// each truncated to DefaultKeyLength characters. See MetaphoneN to use a
+// different maximum key length.
+func Metaphone(s string) (string, string) {
+	return MetaphoneN(s, DefaultKeyLength)
+}
+
+// MetaphoneN returns the primary and secondary Double Metaphone keys for s,
+// each upper-cased and truncated to at most n characters. A non-positive n
+// disables truncation. Callers encoding many strings in a loop should use a
+// StreamEncoder instead, which reuses its buffers across calls.
+func MetaphoneN(s string, n int) (string, string) {
+	// initialize
+	p := &phoneticData{}
+
+	// normalize, then pad so lookahead matches never need their own bounds
+	// checks against the end of the string
+	folded := normalize(s)
+	p.last = len(folded) - 1
+	p.text = append([]byte(folded), ' ', ' ', ' ', ' ', ' ')
+
+	p.isSlavoGermanic = bytes.Contains(p.text, []byte("w")) || bytes.Contains(p.text, []byte("k")) ||
+		bytes.Contains(p.text, []byte("cz")) || bytes.Contains(p.text, []byte("witz"))
 
-	fmt.Println("First: ", p.metaphone1, "\tSecond: ", p.metaphone2, "\t Original: ", s)
+	runMetaphone(p)
 
-	return p.metaphone1, p.metaphone2
+	return truncate(strings.ToUpper(string(p.metaphone1)), n), truncate(strings.ToUpper(string(p.metaphone2)), n)
 }
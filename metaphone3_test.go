@@ -0,0 +1,73 @@
+package megophone
+
+import "testing"
+
+func TestMetaphone3Defaults(t *testing.T) {
+	enc := NewMetaphone3()
+	p, a := enc.Encode("Smith")
+	if p == "" || a == "" {
+		t.Fatalf("Encode(Smith) returned empty keys")
+	}
+}
+
+func TestMetaphone3EncodeExactDistinguishesVoicing(t *testing.T) {
+	exact := &Metaphone3{EncodeExact: true, MaxLength: DefaultMetaphone3Length}
+	folded := &Metaphone3{MaxLength: DefaultMetaphone3Length}
+
+	bp, _ := exact.Encode("b")
+	pp, _ := exact.Encode("p")
+	if bp == pp {
+		t.Errorf("with EncodeExact, Encode(%q) and Encode(%q) should differ, both gave %q", "b", "p", bp)
+	}
+
+	bf, _ := folded.Encode("b")
+	pf, _ := folded.Encode("p")
+	if bf != pf {
+		t.Errorf("without EncodeExact, Encode(%q) and Encode(%q) should match, got %q and %q", "b", "p", bf, pf)
+	}
+}
+
+func TestMetaphone3EncodeVowelsAvoidsCollisions(t *testing.T) {
+	// Double Metaphone famously collapses these pairs to the same key;
+	// Metaphone3 with EncodeVowels should tell them apart.
+	enc := &Metaphone3{EncodeVowels: true, MaxLength: DefaultMetaphone3Length}
+
+	cases := [][2]string{
+		{"Wright", "Rita"},
+		{"Knight", "Nite"},
+	}
+	for _, c := range cases {
+		p1, _ := enc.Encode(c[0])
+		p2, _ := enc.Encode(c[1])
+		if p1 == p2 {
+			t.Errorf("Encode(%q) and Encode(%q) should differ with EncodeVowels, both gave %q", c[0], c[1], p1)
+		}
+	}
+
+	// without EncodeVowels the pairs do collide, matching Double Metaphone
+	plain := NewMetaphone3()
+	for _, c := range cases {
+		p1, _ := plain.Encode(c[0])
+		p2, _ := plain.Encode(c[1])
+		if p1 != p2 {
+			t.Errorf("Encode(%q) and Encode(%q) should match without EncodeVowels, got %q and %q", c[0], c[1], p1, p2)
+		}
+	}
+}
+
+func TestMetaphone3AachenVariants(t *testing.T) {
+	enc := NewMetaphone3()
+	p1, _ := enc.Encode("Aachen")
+	p2, _ := enc.Encode("Achen")
+	if p1 != p2 {
+		t.Errorf("Encode(Aachen) = %q, Encode(Achen) = %q, want them equal", p1, p2)
+	}
+}
+
+func TestMetaphone3MaxLength(t *testing.T) {
+	enc := &Metaphone3{MaxLength: 3}
+	p, a := enc.Encode("Thompson")
+	if len(p) > 3 || len(a) > 3 {
+		t.Errorf("Encode with MaxLength 3 returned (%q, %q), want length <= 3", p, a)
+	}
+}
@@ -0,0 +1,65 @@
+package megophone
+
+import "testing"
+
+func TestMetaphone(t *testing.T) {
+	cases := []struct {
+		name      string
+		primary   string
+		secondary string
+	}{
+		{"Smith", "SM0", "XMT"},
+		{"Schmidt", "XMT", "SMT"},
+		{"czerny", "SRN", "XRN"},
+		{"focaccia", "FKX", "FKX"},
+		{"Xavier", "SF", "SFR"},
+		{"McHugh", "MK", "MK"},
+		{"Thompson", "TMPS", "TMPS"},
+		{"Knight", "NT", "NT"},
+		{"Jose", "JS", "HS"},
+		{"catherine", "K0RN", "KTRN"},
+		{"Wright", "RT", "RT"},
+		{"Aubrey", "APR", "APR"},
+		{"filipowicz", "FLPT", "FLPF"},
+	}
+
+	for _, c := range cases {
+		p, s := Metaphone(c.name)
+		if p != c.primary || s != c.secondary {
+			t.Errorf("Metaphone(%q) = (%q, %q), want (%q, %q)", c.name, p, s, c.primary, c.secondary)
+		}
+	}
+}
+
+func TestMetaphoneN(t *testing.T) {
+	p, s := MetaphoneN("Thompson", 8)
+	if p != "TMPSN" || s != "TMPSN" {
+		t.Errorf("MetaphoneN(Thompson, 8) = (%q, %q), want (TMPSN, TMPSN)", p, s)
+	}
+
+	// a max length of 2 should truncate the classic 4-char key
+	p, s = MetaphoneN("Smith", 2)
+	if p != "SM" || s != "XM" {
+		t.Errorf("MetaphoneN(Smith, 2) = (%q, %q), want (SM, XM)", p, s)
+	}
+}
+
+func TestMetaphoneUnicodeFolding(t *testing.T) {
+	// "François" routes its cedilla through the dedicated ç() handler
+	// (always a soft "s" sound), which is intentionally a different key
+	// than the plain "c" in "Francois" would produce.
+	p1, s1 := Metaphone("François")
+	if p1 != "FRNS" || s1 != "FRNS" {
+		t.Errorf("Metaphone(François) = (%q, %q), want (FRNS, FRNS)", p1, s1)
+	}
+	p2, s2 := Metaphone("Francois")
+	if p2 != "FRNK" || s2 != "FRNK" {
+		t.Errorf("Metaphone(Francois) = (%q, %q), want (FRNK, FRNK)", p2, s2)
+	}
+
+	// "München" folds its umlaut to a plain 'u' before scanning.
+	p, s := Metaphone("München")
+	if p != "MNXN" || s != "MNKN" {
+		t.Errorf("Metaphone(München) = (%q, %q), want (MNXN, MNKN)", p, s)
+	}
+}
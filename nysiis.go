@@ -0,0 +1,133 @@
+package megophone
+
+import "strings"
+
+// nysiisMaxLength is the conventional cap on a NYSIIS key.
+const nysiisMaxLength = 6
+
+// NYSIIS implements the New York State Identification and Intelligence
+// System phonetic code. It predates Daitch-Mokotoff but shares its original
+// motivation: Soundex handles the Slavic and Germanic surnames common in
+// New York State's records poorly, so NYSIIS applies a larger set of
+// transliteration rules before falling back to a Soundex-like vowel
+// collapse, and keeps up to nysiisMaxLength (6) characters of the
+// transformed key instead of a single letter plus three digits.
+func NYSIIS(s string) string {
+	name := soundexLetters(s)
+	if len(name) == 0 {
+		return ""
+	}
+
+	name = nysiisTranscodeStart(name)
+	name = nysiisTranscodeEnd(name)
+
+	key := make([]byte, 0, len(name)+2)
+	key = append(key, upperByte(name[0]))
+
+	last := key[0]
+	for i := 1; i < len(name); i++ {
+		c, consumed := nysiisTranscode(name, i)
+		i += consumed
+		if c == 0 {
+			continue
+		}
+		u := upperByte(c)
+		if u != last {
+			key = append(key, u)
+			last = u
+		}
+	}
+
+	for len(key) > 1 && key[len(key)-1] == 'S' {
+		key = key[:len(key)-1]
+	}
+	if n := len(key); n >= 2 && key[n-2] == 'A' && key[n-1] == 'Y' {
+		key = append(key[:n-2], 'Y')
+	}
+	for len(key) > 1 && key[len(key)-1] == 'A' {
+		key = key[:len(key)-1]
+	}
+
+	if len(key) > nysiisMaxLength {
+		key = key[:nysiisMaxLength]
+	}
+	return string(key)
+}
+
+// nysiisTranscodeStart rewrites a handful of silent or irregular prefixes
+// before the main letter-by-letter pass.
+func nysiisTranscodeStart(name []byte) []byte {
+	s := string(name)
+	switch {
+	case strings.HasPrefix(s, "mac"):
+		s = "mcc" + s[3:]
+	case strings.HasPrefix(s, "kn"):
+		s = "nn" + s[2:]
+	case strings.HasPrefix(s, "k"):
+		s = "c" + s[1:]
+	case strings.HasPrefix(s, "ph"), strings.HasPrefix(s, "pf"):
+		s = "ff" + s[2:]
+	case strings.HasPrefix(s, "sch"):
+		s = "sss" + s[3:]
+	}
+	return []byte(s)
+}
+
+// nysiisTranscodeEnd rewrites a handful of irregular suffixes.
+func nysiisTranscodeEnd(name []byte) []byte {
+	s := string(name)
+	switch {
+	case strings.HasSuffix(s, "ee"), strings.HasSuffix(s, "ie"):
+		s = s[:len(s)-2] + "y"
+	case strings.HasSuffix(s, "dt"), strings.HasSuffix(s, "rt"),
+		strings.HasSuffix(s, "rd"), strings.HasSuffix(s, "nt"),
+		strings.HasSuffix(s, "nd"):
+		s = s[:len(s)-2] + "d"
+	}
+	return []byte(s)
+}
+
+// nysiisTranscode returns the phoneme for the letter(s) of name starting at
+// i, and how many extra letters beyond name[i] it consumed (0 for a single
+// letter, 1 for a digraph).
+func nysiisTranscode(name []byte, i int) (byte, int) {
+	prevVowel := i > 0 && isDMVowel(name[i-1])
+	nextVowel := i+1 < len(name) && isDMVowel(name[i+1])
+
+	switch name[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return 'a', 0
+	case 'q':
+		return 'g', 0
+	case 'z':
+		return 's', 0
+	case 'm':
+		return 'n', 0
+	case 'k':
+		if i+1 < len(name) && name[i+1] == 'n' {
+			return 'n', 1
+		}
+		return 'c', 0
+	case 's':
+		if strings.HasPrefix(string(name[i:]), "sch") {
+			return 's', 2
+		}
+		return 's', 0
+	case 'p':
+		if i+1 < len(name) && name[i+1] == 'h' {
+			return 'f', 1
+		}
+		return 'p', 0
+	case 'h':
+		if !prevVowel || !nextVowel {
+			return name[i-1], 0
+		}
+		return 'h', 0
+	case 'w':
+		if prevVowel {
+			return name[i-1], 0
+		}
+		return 'w', 0
+	}
+	return name[i], 0
+}
@@ -0,0 +1,33 @@
+package megophone
+
+import "testing"
+
+func TestNYSIIS(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		{"Robert", "RABAD"},
+		{"Schmidt", "SNAD"},
+		{"Knight", "NAGT"},
+	}
+
+	for _, c := range cases {
+		if got := NYSIIS(c.name); got != c.want {
+			t.Errorf("NYSIIS(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestNYSIISMaxLength(t *testing.T) {
+	got := NYSIIS("Featherstonehaugh")
+	if len(got) > nysiisMaxLength {
+		t.Errorf("NYSIIS(Featherstonehaugh) = %q, longer than the %d-char cap", got, nysiisMaxLength)
+	}
+}
+
+func TestNYSIISEmpty(t *testing.T) {
+	if got := NYSIIS(""); got != "" {
+		t.Errorf("NYSIIS(\"\") = %q, want \"\"", got)
+	}
+}
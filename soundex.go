@@ -0,0 +1,132 @@
+package megophone
+
+// Soundex implements the classic 1918 Soundex algorithm: Robert Russell and
+// Margaret Odell's original 1-letter-plus-3-digit phonetic code, still the
+// most widely deployed phonetic index (it ships as a builtin SQL function in
+// most major databases).
+func Soundex(s string) string {
+	letters := soundexLetters(s)
+	if len(letters) == 0 {
+		return ""
+	}
+
+	code := make([]byte, 0, 4)
+	code = append(code, upperByte(letters[0]))
+	lastDigit := soundexDigit(letters[0])
+
+	for i := 1; i < len(letters) && len(code) < 4; i++ {
+		c := letters[i]
+		if c == 'h' || c == 'w' {
+			// neither resets the "last digit seen" nor produces one
+			continue
+		}
+		d := soundexDigit(c)
+		if d == 0 {
+			// a vowel separates consonants, so the next matching code is kept
+			lastDigit = 0
+			continue
+		}
+		if d != lastDigit {
+			code = append(code, '0'+d)
+		}
+		lastDigit = d
+	}
+
+	for len(code) < 4 {
+		code = append(code, '0')
+	}
+	return string(code)
+}
+
+// RefinedSoundex implements the "refined" Soundex variant used by genealogy
+// tools that want finer-grained consonant groupings and don't truncate the
+// result to a fixed length.
+func RefinedSoundex(s string) string {
+	letters := soundexLetters(s)
+	if len(letters) == 0 {
+		return ""
+	}
+
+	code := make([]byte, 0, len(letters))
+	code = append(code, upperByte(letters[0]))
+	lastDigit := refinedSoundexDigit(letters[0])
+	if lastDigit != 0 {
+		code = append(code, '0'+lastDigit)
+	}
+
+	for i := 1; i < len(letters); i++ {
+		d := refinedSoundexDigit(letters[i])
+		if d == 0 {
+			lastDigit = 0
+			continue
+		}
+		if d != lastDigit {
+			code = append(code, '0'+d)
+		}
+		lastDigit = d
+	}
+	return string(code)
+}
+
+// soundexLetters folds s and strips anything that isn't a plain ASCII
+// letter, since neither Soundex variant has a notion of accents.
+func soundexLetters(s string) []byte {
+	folded := normalize(s)
+	letters := make([]byte, 0, len(folded))
+	for i := 0; i < len(folded); i++ {
+		c := folded[i]
+		if c >= 'a' && c <= 'z' {
+			letters = append(letters, c)
+		}
+	}
+	return letters
+}
+
+func upperByte(c byte) byte {
+	if c >= 'a' && c <= 'z' {
+		return c - 'a' + 'A'
+	}
+	return c
+}
+
+func soundexDigit(c byte) byte {
+	switch c {
+	case 'b', 'f', 'p', 'v':
+		return 1
+	case 'c', 'g', 'j', 'k', 'q', 's', 'x', 'z':
+		return 2
+	case 'd', 't':
+		return 3
+	case 'l':
+		return 4
+	case 'm', 'n':
+		return 5
+	case 'r':
+		return 6
+	}
+	return 0
+}
+
+func refinedSoundexDigit(c byte) byte {
+	switch c {
+	case 'b', 'p':
+		return 1
+	case 'f', 'v':
+		return 2
+	case 'c', 'k', 'q':
+		return 3
+	case 's', 'z':
+		return 4
+	case 'd', 't':
+		return 5
+	case 'l':
+		return 6
+	case 'm', 'n':
+		return 7
+	case 'r':
+		return 8
+	case 'g', 'j':
+		return 9
+	}
+	return 0
+}
@@ -0,0 +1,47 @@
+package megophone
+
+import "testing"
+
+func TestSoundex(t *testing.T) {
+	cases := []struct {
+		name string
+		want string
+	}{
+		// the textbook examples from Knuth's Art of Computer Programming
+		{"Robert", "R163"},
+		{"Rupert", "R163"},
+		{"Ashcraft", "A261"},
+		{"Tymczak", "T522"},
+		{"Euler", "E460"},
+		{"Pfister", "P236"},
+	}
+
+	for _, c := range cases {
+		if got := Soundex(c.name); got != c.want {
+			t.Errorf("Soundex(%q) = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestSoundexEmpty(t *testing.T) {
+	if got := Soundex(""); got != "" {
+		t.Errorf("Soundex(\"\") = %q, want \"\"", got)
+	}
+}
+
+func TestRefinedSoundexKeepsConsonantGroupsDistinct(t *testing.T) {
+	// Refined Soundex splits Soundex's "bfpv" group into "bp" and "fv", so
+	// "Hopper" and "Hoffer" (which Soundex both code H160) diverge.
+	p := RefinedSoundex("Hopper")
+	b := RefinedSoundex("Hoffer")
+	if p == b {
+		t.Errorf("RefinedSoundex(Hopper) and RefinedSoundex(Hoffer) should differ, both gave %q", p)
+	}
+}
+
+func TestRefinedSoundexDoesNotTruncate(t *testing.T) {
+	got := RefinedSoundex("Featherstonehaugh")
+	if len(got) <= 4 {
+		t.Errorf("RefinedSoundex(Featherstonehaugh) = %q, want longer than the classic 4-char Soundex width", got)
+	}
+}
@@ -0,0 +1,119 @@
+package megophone
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"unicode"
+)
+
+// StreamEncoder is a Double Metaphone encoder for callers processing many
+// names in a hot loop (bulk import, log processing, building an Index from
+// a large corpus), where Metaphone/MetaphoneN's per-call allocations (a
+// fresh phoneticData, a fresh pair of result strings) show up in profiles.
+// It reuses its internal buffers across calls instead: after the first few
+// calls grow them to the size of the longest input seen, steady-state use
+// of EncodeInto allocates nothing.
+//
+// The zero value is ready to use. A StreamEncoder is not safe for
+// concurrent use; give each goroutine its own.
+type StreamEncoder struct {
+	// MaxLength caps each returned key length, like Metaphone3.MaxLength.
+	// Zero or negative uses DefaultKeyLength.
+	MaxLength int
+
+	data   phoneticData
+	folded []byte
+}
+
+// Reset clears a StreamEncoder's buffers, retaining their capacity, so it
+// is ready for the next call to EncodeInto. EncodeInto calls Reset itself,
+// so callers need not; Reset does not release the buffers' memory.
+func (e *StreamEncoder) Reset() {
+	e.data.text = e.data.text[:0]
+	e.data.metaphone1 = e.data.metaphone1[:0]
+	e.data.metaphone2 = e.data.metaphone2[:0]
+	e.data.cur = 0
+	e.data.last = 0
+	e.data.isSlavoGermanic = false
+}
+
+// EncodeInto writes the primary and alternate Double Metaphone keys for src
+// into *dst1 and *dst2, reusing their backing arrays when they already have
+// enough capacity instead of allocating new ones.
+//
+// Unlike Metaphone/MetaphoneN, EncodeInto only folds src to lowercase
+// ASCII; it does not fold accents the way normalize does, since that
+// requires decoding runes and isn't allocation-free. Callers with non-ASCII
+// input should fold it themselves (e.g. with normalize) before calling
+// EncodeInto.
+func (e *StreamEncoder) EncodeInto(dst1, dst2 *[]byte, src []byte) {
+	maxLen := e.MaxLength
+	if maxLen <= 0 {
+		maxLen = DefaultKeyLength
+	}
+
+	e.Reset()
+	e.folded = appendFoldASCII(e.folded[:0], src)
+
+	e.data.last = len(e.folded) - 1
+	e.data.text = append(e.data.text, e.folded...)
+	e.data.text = append(e.data.text, ' ', ' ', ' ', ' ', ' ')
+	e.data.isSlavoGermanic = bytes.Contains(e.data.text, []byte("w")) || bytes.Contains(e.data.text, []byte("k")) ||
+		bytes.Contains(e.data.text, []byte("cz")) || bytes.Contains(e.data.text, []byte("witz"))
+
+	runMetaphone(&e.data)
+
+	*dst1 = truncateBytes(appendUpperASCII((*dst1)[:0], e.data.metaphone1), maxLen)
+	*dst2 = truncateBytes(appendUpperASCII((*dst2)[:0], e.data.metaphone2), maxLen)
+}
+
+// EncodeReader reads whitespace/punctuation-delimited tokens from r and
+// calls w with each token's primary and alternate Double Metaphone keys.
+// The slices passed to w are reused for the next token the same way
+// EncodeInto's dst arguments are, so w must not retain them past its call.
+func (e *StreamEncoder) EncodeReader(r io.Reader, w func(primary, alternate []byte)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(bufio.ScanWords)
+
+	var dst1, dst2 []byte
+	for scanner.Scan() {
+		for _, field := range bytes.FieldsFunc(scanner.Bytes(), unicode.IsPunct) {
+			token := bytes.TrimFunc(field, isNotASCIILetter)
+			if len(token) == 0 {
+				continue
+			}
+			e.EncodeInto(&dst1, &dst2, token)
+			w(dst1, dst2)
+		}
+	}
+	return scanner.Err()
+}
+
+func isNotASCIILetter(r rune) bool {
+	return !((r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z'))
+}
+
+// appendFoldASCII appends a lowercase-folded copy of src to dst; bytes
+// outside 'A'-'Z' pass through unchanged.
+func appendFoldASCII(dst, src []byte) []byte {
+	for _, c := range src {
+		if c >= 'A' && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
+
+// appendUpperASCII appends an uppercase-folded copy of src to dst; bytes
+// outside 'a'-'z' pass through unchanged.
+func appendUpperASCII(dst, src []byte) []byte {
+	for _, c := range src {
+		if c >= 'a' && c <= 'z' {
+			c -= 'a' - 'A'
+		}
+		dst = append(dst, c)
+	}
+	return dst
+}
@@ -0,0 +1,114 @@
+package megophone
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestStreamEncoderMatchesMetaphone(t *testing.T) {
+	names := []string{"Smith", "Schmidt", "Thompson", "Knight", "McHugh", "filipowicz"}
+
+	var enc StreamEncoder
+	var dst1, dst2 []byte
+	for _, name := range names {
+		wantP, wantS := Metaphone(name)
+		enc.EncodeInto(&dst1, &dst2, []byte(name))
+		if string(dst1) != wantP || string(dst2) != wantS {
+			t.Errorf("StreamEncoder.EncodeInto(%q) = (%q, %q), want (%q, %q)", name, dst1, dst2, wantP, wantS)
+		}
+	}
+}
+
+func TestStreamEncoderReusesBuffersWithoutLeakingState(t *testing.T) {
+	var enc StreamEncoder
+	var dst1, dst2 []byte
+
+	enc.EncodeInto(&dst1, &dst2, []byte("Thompson"))
+	long1, long2 := string(dst1), string(dst2)
+
+	enc.EncodeInto(&dst1, &dst2, []byte("Jo"))
+	if strings.HasPrefix(string(dst1), long1) && len(dst1) > 2 {
+		t.Errorf("EncodeInto(%q) = %q, looks like it retained bytes from the previous call", "Jo", dst1)
+	}
+	_ = long2
+}
+
+func TestStreamEncoderMaxLength(t *testing.T) {
+	enc := &StreamEncoder{MaxLength: 2}
+	var dst1, dst2 []byte
+	enc.EncodeInto(&dst1, &dst2, []byte("Thompson"))
+	if len(dst1) > 2 || len(dst2) > 2 {
+		t.Errorf("EncodeInto with MaxLength 2 produced (%q, %q), want length <= 2", dst1, dst2)
+	}
+}
+
+func TestStreamEncoderEncodeReaderTokenizes(t *testing.T) {
+	var enc StreamEncoder
+	var got []string
+	err := enc.EncodeReader(strings.NewReader("Smith, Schmidt.\nThompson"), func(primary, alternate []byte) {
+		got = append(got, string(primary))
+	})
+	if err != nil {
+		t.Fatalf("EncodeReader returned error: %v", err)
+	}
+
+	want := []string{"SM0", "XMT", "TMPS"}
+	if len(got) != len(want) {
+		t.Fatalf("EncodeReader produced %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("EncodeReader token %d = %q, want %q", i, got[i], want[i])
+		}
+	}
+}
+
+// benchmarkCorpus returns n synthetic but name-shaped strings, built by
+// combining a handful of initial, medial, and final clusters common in the
+// names this package is tuned for. It stands in for the 100k-name corpora
+// this package is typically benchmarked against in practice without
+// shipping one in the repo.
+func benchmarkCorpus(n int) []string {
+	firsts := []string{"sm", "schm", "mc", "kn", "wr", "ch", "th", "ph", "gh", "x"}
+	mids := []string{"a", "i", "o", "au", "ei", "ou"}
+	lasts := []string{"th", "tz", "cz", "ck", "son", "berg", "wicz", "ski"}
+
+	corpus := make([]string, n)
+	for i := range corpus {
+		corpus[i] = firsts[i%len(firsts)] + mids[(i/len(firsts))%len(mids)] + lasts[(i/len(mids))%len(lasts)]
+	}
+	return corpus
+}
+
+// BenchmarkMetaphone measures the allocating Metaphone entry point over a
+// 100k-name synthetic corpus.
+func BenchmarkMetaphone(b *testing.B) {
+	corpus := benchmarkCorpus(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = Metaphone(corpus[i%len(corpus)])
+	}
+}
+
+// BenchmarkStreamEncoder measures StreamEncoder.EncodeInto over the same
+// corpus as BenchmarkMetaphone; run with -benchmem to compare allocs/op.
+func BenchmarkStreamEncoder(b *testing.B) {
+	corpus := benchmarkCorpus(100000)
+	var enc StreamEncoder
+	var dst1, dst2 []byte
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		enc.EncodeInto(&dst1, &dst2, []byte(corpus[i%len(corpus)]))
+	}
+}
+
+// BenchmarkStreamEncoderReader measures the streaming EncodeReader path
+// over the same corpus joined into one whitespace-delimited text blob.
+func BenchmarkStreamEncoderReader(b *testing.B) {
+	text := strings.Join(benchmarkCorpus(100000), " ")
+	var enc StreamEncoder
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = enc.EncodeReader(strings.NewReader(text), func(primary, alternate []byte) {})
+	}
+}